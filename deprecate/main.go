@@ -4,57 +4,206 @@
 package main
 
 import (
+	"bytes"
+	"flag"
 	"fmt"
+	"go/format"
+	"go/token"
+	"go/types"
 	"io/fs"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
+
+	"golang.org/x/tools/go/packages"
+)
+
+const (
+	shimFileName = "deprecation.go"
+	manifestPath = "deprecate/shims.txt"
+	buildTag     = "//go:build !nodeprecationshim\n// +build !nodeprecationshim\n"
+	commonAlias  = "common"
 )
 
+var diffMode = flag.Bool("diff", false, "check that the generated shims match what's on disk instead of writing them; exits non-zero on drift")
+
 func main() {
-	err := filepath.WalkDir(".", func(path string, d fs.DirEntry, err error) error {
-		if d.IsDir() {
-			processDir(path)
+	flag.Parse()
+
+	allowed, err := loadManifest(manifestPath)
+	if err != nil {
+		panic(err)
+	}
+
+	drifted := false
+	err = filepath.WalkDir(".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
 		}
+		if strings.HasPrefix(d.Name(), ".") && path != "." {
+			return fs.SkipDir
+		}
+		if !allowed[filepath.Clean(path)] {
+			return nil
+		}
+
+		changed, err := processDir(path)
+		if err != nil {
+			fmt.Println(path, "-", err)
+			return nil
+		}
+		drifted = drifted || changed
 		return nil
 	})
 	if err != nil {
 		panic(err)
 	}
 
+	if *diffMode && drifted {
+		os.Exit(1)
+	}
+}
+
+// loadManifest reads manifestPath for the newline separated list of directories, relative to
+// the module root, that are genuinely deprecated and should have their re-export shim
+// generated. A directory not listed here is left untouched even if its package name happens to
+// collide with a storj.io/common package of the same name - the generator must never touch a
+// package just because of a naming coincidence.
+func loadManifest(path string) (map[string]bool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return map[string]bool{}, nil
+		}
+		return nil, err
+	}
+
+	allowed := make(map[string]bool)
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		allowed[filepath.Clean(line)] = true
+	}
+	return allowed, nil
 }
 
-func processDir(path string) {
+// processDir regenerates, or in diff mode checks, the deprecation shim for the package rooted
+// at path. The caller is responsible for only invoking it for directories listed in the
+// manifest loaded by loadManifest. It returns whether the generated shim differs from what's
+// on disk.
+func processDir(path string) (changed bool, err error) {
 	goFile := pickAGoFile(path)
 	if goFile == "" {
-		return
+		return false, nil
 	}
 	pkgName := findPackage(goFile)
-	deprecation := `// Copyright (C) 2024 Storj Labs, Inc.
-// See LICENSE for copying information.
+	if pkgName == "main" {
+		return false, nil
+	}
+
+	commonImportPath := "storj.io/common/" + pkgName
 
-// Deprecated: Use storj.io/common/PACKAGE instead.
-package PACKAGE
-`
-	deprecation = strings.ReplaceAll(deprecation, "PACKAGE", pkgName)
-	fmt.Println(path, pkgName)
-	err := os.WriteFile(filepath.Join(path, "deprecation.go"), []byte(deprecation), 0644)
+	cfg := &packages.Config{Mode: packages.NeedName | packages.NeedTypes | packages.NeedImports | packages.NeedDeps}
+	pkgs, err := packages.Load(cfg, commonImportPath)
 	if err != nil {
-		panic(err)
+		return false, fmt.Errorf("loading %s: %w", commonImportPath, err)
+	}
+	if len(pkgs) != 1 || pkgs[0].Types == nil || len(pkgs[0].Errors) > 0 {
+		fmt.Println(path, pkgName, "- no matching", commonImportPath, "package, skipping")
+		return false, nil
 	}
+
+	shimPath := filepath.Join(path, shimFileName)
+	existing, _ := os.ReadFile(shimPath)
+
+	generated, err := generateShim(pkgName, commonImportPath, copyrightYear(existing), pkgs[0].Types)
+	if err != nil {
+		return false, fmt.Errorf("generating shim for %s: %w", pkgName, err)
+	}
+
+	if bytes.Equal(existing, generated) {
+		return false, nil
+	}
+
+	if *diffMode {
+		fmt.Println(shimPath, "is out of date")
+		return true, nil
+	}
+
+	fmt.Println(path, pkgName, "->", commonImportPath)
+	return true, os.WriteFile(shimPath, generated, 0644)
 }
 
-func findPackage(file string) string {
-	raw := Must(os.ReadFile(file))
-	for _, line := range strings.Split(string(raw), "\n") {
-		if strings.HasPrefix(line, "package") {
-			pkgName := strings.TrimSpace(strings.TrimPrefix(line, "package"))
-			if !strings.Contains(pkgName, "_test") {
-				return pkgName
-			}
+// copyrightYearPattern matches the year stamped in a generated shim's header.
+var copyrightYearPattern = regexp.MustCompile(`// Copyright \(C\) (\d+) Storj Labs, Inc\.`)
+
+// copyrightYear returns the year already stamped in existing's header, if any, so that
+// regenerating an otherwise-unchanged shim doesn't report drift just because the calendar
+// turned over; a shim that doesn't exist yet (existing is empty) gets the current year.
+func copyrightYear(existing []byte) int {
+	if m := copyrightYearPattern.FindSubmatch(existing); m != nil {
+		if year, err := strconv.Atoi(string(m[1])); err == nil {
+			return year
 		}
 	}
-	panic("Didn't find the package name " + file)
+	return time.Now().Year()
+}
+
+// generateShim renders a deprecation.go that re-exports every identifier common exports, so
+// callers of pkgName keep working unmodified while the implementation lives at
+// storj.io/common. The build tag lets a downstream module turn the shim off if it needs to
+// keep the original, un-forwarded package instead.
+func generateShim(pkgName, importPath string, year int, common *types.Package) ([]byte, error) {
+	scope := common.Scope()
+	names := scope.Names()
+	sort.Strings(names)
+
+	var typeDecls, constDecls, varDecls []string
+	for _, name := range names {
+		if !token.IsExported(name) {
+			continue
+		}
+
+		switch scope.Lookup(name).(type) {
+		case *types.TypeName:
+			typeDecls = append(typeDecls, fmt.Sprintf("\t%s = %s.%s", name, commonAlias, name))
+		case *types.Const:
+			constDecls = append(constDecls, fmt.Sprintf("\t%s = %s.%s", name, commonAlias, name))
+		case *types.Var, *types.Func:
+			// plain vars and funcs forward the same way: a var of the same name bound to
+			// the common package's value, including function values.
+			varDecls = append(varDecls, fmt.Sprintf("\t%s = %s.%s", name, commonAlias, name))
+		}
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprint(&buf, buildTag)
+	fmt.Fprintf(&buf, "\n// Copyright (C) %d Storj Labs, Inc.\n// See LICENSE for copying information.\n\n", year)
+	fmt.Fprint(&buf, "// Code generated by storj.io/private/deprecate. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&buf, "// Deprecated: use %s instead.\npackage %s\n\n", importPath, pkgName)
+	fmt.Fprintf(&buf, "import %s %q\n\n", commonAlias, importPath)
+
+	writeBlock(&buf, "type", typeDecls)
+	writeBlock(&buf, "const", constDecls)
+	writeBlock(&buf, "var", varDecls)
+
+	return format.Source(buf.Bytes())
+}
+
+func writeBlock(buf *bytes.Buffer, keyword string, decls []string) {
+	if len(decls) == 0 {
+		return
+	}
+	fmt.Fprintf(buf, "%s (\n%s\n)\n\n", keyword, strings.Join(decls, "\n"))
 }
 
 func pickAGoFile(path string) string {
@@ -66,6 +215,19 @@ func pickAGoFile(path string) string {
 	return ""
 }
 
+func findPackage(file string) string {
+	raw := Must(os.ReadFile(file))
+	for _, line := range strings.Split(string(raw), "\n") {
+		if strings.HasPrefix(line, "package") {
+			pkgName := strings.TrimSpace(strings.TrimPrefix(line, "package"))
+			if !strings.Contains(pkgName, "_test") {
+				return pkgName
+			}
+		}
+	}
+	panic("Didn't find the package name " + file)
+}
+
 func Must[T any](t T, err error) T {
 	if err != nil {
 		panic(err)
@@ -6,15 +6,22 @@ package process
 import (
 	"context"
 	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
 	"github.com/spacemonkeygo/monkit/v3"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
 	"go.opentelemetry.io/otel/propagation"
 	"go.opentelemetry.io/otel/sdk/resource"
 	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
-	"os"
-	"path/filepath"
-	"time"
 
 	"go.opentelemetry.io/otel/sdk/trace"
 	ctxtrace "go.opentelemetry.io/otel/trace"
@@ -22,6 +29,7 @@ import (
 
 	"storj.io/common/identity"
 	"storj.io/common/telemetry"
+	"storj.io/private/version"
 )
 
 var (
@@ -32,10 +40,15 @@ var (
 	tracingAppEnvironment = flag.String("tracing.app-environment", flagDefault("dev", "release"), "application environment")
 	tracingQueueSize      = flag.Int("tracing.queue-size", 2048, "the maximum queue size to buffer spans for delayed processing.")
 	tracingBatchSize      = flag.Int("tracing.batch-size", 512, "the maximum number of spans to process in a single batch")
+
+	tracingExporterKind    = flag.String("tracing.exporter", "jaeger", "trace exporter to use: jaeger, otlp-http or otlp-grpc")
+	tracingOTLPEndpoint    = flag.String("tracing.otlp.endpoint", "", "OTLP collector endpoint, e.g. otel-collector:4317")
+	tracingOTLPInsecure    = flag.Bool("tracing.otlp.insecure", false, "disable TLS when talking to the OTLP collector")
+	tracingOTLPHeaders     = flag.String("tracing.otlp.headers", "", "comma separated key=value headers sent with every OTLP export request")
+	tracingOTLPCompression = flag.String("tracing.otlp.compression", "", "compression to use for OTLP export, e.g. gzip")
 )
 
 const (
-	instanceIDKey  = "instanceID"
 	hostnameKey    = "hostname"
 	environmentKey = "environment"
 )
@@ -63,13 +76,17 @@ func InitTracingWithHostname(ctx context.Context, log *zap.Logger, exp func(stri
 
 func initTracing(ctx context.Context, log *zap.Logger, exp func(string) trace.SpanExporter, instanceID, hostname string) (cancel func(), err error) {
 
-	if exp == nil {
-		log.Debug("Tracing exporter not provided")
+	if !*tracingEnabled {
+		log.Debug("Anonymized tracing disabled")
 		return nil, nil
 	}
 
-	if !*tracingEnabled {
-		log.Debug("Anonymized tracing disabled")
+	exporter, err := buildExporter(ctx, exp)
+	if err != nil {
+		return nil, err
+	}
+	if exporter == nil {
+		log.Debug("Tracing exporter not provided")
 		return nil, nil
 	}
 
@@ -84,6 +101,11 @@ func initTracing(ctx context.Context, log *zap.Logger, exp func(string) trace.Sp
 		processName = processName[:maxInstanceLength]
 	}
 
+	attrs := NewResourceAttributes(processName, instanceID).
+		With(attribute.String(hostnameKey, hostname)).
+		With(attribute.String(environmentKey, *tracingAppEnvironment)).
+		Attributes()
+
 	tp := trace.NewTracerProvider(
 		trace.WithSampler(
 			trace.ParentBased(
@@ -93,16 +115,11 @@ func initTracing(ctx context.Context, log *zap.Logger, exp func(string) trace.Sp
 				trace.WithLocalParentSampled(trace.TraceIDRatioBased(*tracingSamplingRate)),
 				trace.WithLocalParentNotSampled(trace.TraceIDRatioBased(*tracingSamplingRate)))),
 		trace.WithSpanProcessor(
-			trace.NewBatchSpanProcessor(exp(*tracingAgent),
+			trace.NewBatchSpanProcessor(exporter,
 				trace.WithMaxExportBatchSize(*tracingBatchSize),
 				trace.WithMaxQueueSize(*tracingQueueSize))),
 		trace.WithResource(
-			resource.NewWithAttributes(
-				semconv.SchemaURL,
-				semconv.ServiceName(processName),
-				attribute.String(instanceIDKey, instanceID),
-				attribute.String(hostnameKey, hostname),
-				attribute.String(environmentKey, *tracingAppEnvironment))),
+			resource.NewWithAttributes(semconv.SchemaURL, attrs...)),
 	)
 	otel.SetTracerProvider(tp)
 	otel.SetTextMapPropagator(propagation.TraceContext{})
@@ -117,17 +134,147 @@ func initTracing(ctx context.Context, log *zap.Logger, exp func(string) trace.Sp
 
 }
 
+// buildExporter picks a trace.SpanExporter according to the --tracing.exporter flag. For
+// "otlp-http" and "otlp-grpc" it builds an OTel exporter from the --tracing.otlp.* flags; for
+// anything else (the default, "jaeger") it falls back to the caller-supplied exp, preserving
+// the historical behavior of InitTracing.
+func buildExporter(ctx context.Context, exp func(string) trace.SpanExporter) (trace.SpanExporter, error) {
+	headers := parseHeaders(*tracingOTLPHeaders)
+
+	switch *tracingExporterKind {
+	case "otlp-http":
+		opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(*tracingOTLPEndpoint)}
+		if *tracingOTLPInsecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		if len(headers) > 0 {
+			opts = append(opts, otlptracehttp.WithHeaders(headers))
+		}
+		if *tracingOTLPCompression == "gzip" {
+			opts = append(opts, otlptracehttp.WithCompression(otlptracehttp.GzipCompression))
+		}
+		return otlptracehttp.New(ctx, opts...)
+
+	case "otlp-grpc":
+		opts := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(*tracingOTLPEndpoint)}
+		if *tracingOTLPInsecure {
+			opts = append(opts, otlptracegrpc.WithInsecure())
+		}
+		if len(headers) > 0 {
+			opts = append(opts, otlptracegrpc.WithHeaders(headers))
+		}
+		if *tracingOTLPCompression != "" {
+			opts = append(opts, otlptracegrpc.WithCompressor(*tracingOTLPCompression))
+		}
+		return otlptracegrpc.New(ctx, opts...)
+
+	default:
+		if exp == nil {
+			return nil, nil
+		}
+		return exp(*tracingAgent), nil
+	}
+}
+
+// parseHeaders turns a "key=value,key2=value2" flag value into a header map, as expected by
+// otlptracehttp.WithHeaders and otlptracegrpc.WithHeaders.
+func parseHeaders(s string) map[string]string {
+	if s == "" {
+		return nil
+	}
+	headers := make(map[string]string)
+	for _, pair := range strings.Split(s, ",") {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			continue
+		}
+		headers[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+	return headers
+}
+
+// ResourceAttributes builds the OpenTelemetry resource attributes attached to every span
+// exported by InitTracing: service.name, service.version (from version.Build) and
+// service.instance.id. Downstream binaries can start from NewResourceAttributes and append
+// their own attributes with With before handing the result to their own resource.New.
+type ResourceAttributes struct {
+	attrs []attribute.KeyValue
+}
+
+// NewResourceAttributes seeds a ResourceAttributes builder for the given process name and
+// tracing instance ID.
+func NewResourceAttributes(processName, instanceID string) *ResourceAttributes {
+	return &ResourceAttributes{
+		attrs: []attribute.KeyValue{
+			semconv.ServiceName(processName),
+			semconv.ServiceVersion(version.Build.Version.String()),
+			semconv.ServiceInstanceID(instanceID),
+		},
+	}
+}
+
+// With appends an additional resource attribute and returns the builder for chaining.
+func (b *ResourceAttributes) With(kv attribute.KeyValue) *ResourceAttributes {
+	b.attrs = append(b.attrs, kv)
+	return b
+}
+
+// Attributes returns the accumulated resource attributes.
+func (b *ResourceAttributes) Attributes() []attribute.KeyValue {
+	return append([]attribute.KeyValue(nil), b.attrs...)
+}
+
+// otelSpans tracks the OTel span MyCoolObserver started for each still-open monkit.Span, so
+// Finish ends exactly the span Start created instead of whatever happens to be in context.
+var otelSpans sync.Map // map[*monkit.Span]ctxtrace.Span
+
+// MyCoolObserver bridges monkit's span tree onto OpenTelemetry, so monkit's existing
+// instrumentation shows up in whatever tracer InitTracing configured.
 type MyCoolObserver func()
 
+// Start opens an OTel span for s, parented either under s's monkit parent (already reflected
+// in s.Context by monkit) or, for a trace root, under any remote span context/baggage carried
+// on ctx, so traces stitch across process boundaries.
 func (m MyCoolObserver) Start(ctx context.Context, s *monkit.Span) context.Context {
-	s.Context, _ = otel.GetTracerProvider().Tracer("").Start(s.Context, s.Func().Scope().Name())
-	otel.GetTracerProvider().Tracer("").Start(s.Context, "test_child_span")
-	return s.Context
+	parent := s.Context
+	if !ctxtrace.SpanContextFromContext(parent).IsValid() {
+		if remote := ctxtrace.SpanContextFromContext(ctx); remote.IsValid() {
+			parent = ctxtrace.ContextWithRemoteSpanContext(parent, remote)
+		}
+		parent = baggage.ContextWithBaggage(parent, baggage.FromContext(ctx))
+	}
+
+	spanCtx, span := otel.GetTracerProvider().Tracer("").Start(parent, s.Func().Scope().Name())
+	otelSpans.Store(s, span)
+
+	s.Context = spanCtx
+	return spanCtx
 }
 
+// Finish ends the OTel span Start opened for s, copying over monkit's annotations as
+// attributes and recording err/panicked as span status.
 func (m MyCoolObserver) Finish(ctx context.Context, s *monkit.Span, err error, panicked bool, finish time.Time) {
-	span := ctxtrace.SpanFromContext(s.Context)
-	span.End()
+	value, ok := otelSpans.LoadAndDelete(s)
+	if !ok {
+		return
+	}
+	span := value.(ctxtrace.Span)
+
+	for _, annotation := range s.Annotations() {
+		span.SetAttributes(attribute.String(annotation.Name, annotation.Value))
+	}
+
+	switch {
+	case err != nil:
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	case panicked:
+		span.SetStatus(codes.Error, "panicked")
+	default:
+		span.SetStatus(codes.Ok, "")
+	}
+
+	span.End(ctxtrace.WithTimestamp(finish))
 }
 
 func nodeIDFromCertPath(ctx context.Context, log *zap.Logger, certPath string) string {
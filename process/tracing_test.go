@@ -0,0 +1,62 @@
+// Copyright (C) 2024 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package process
+
+import (
+	"context"
+	"testing"
+
+	"github.com/spacemonkeygo/monkit/v3"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestMyCoolObserverParentChild(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(tp)
+	defer otel.SetTracerProvider(prev)
+
+	registry := monkit.NewRegistry()
+	mon := registry.Package()
+
+	var observer MyCoolObserver
+	cancel := registry.ObserveTraces(func(trace *monkit.Trace) {
+		trace.ObserveSpansCtx(observer)
+	})
+	defer cancel()
+
+	child := func(ctx context.Context) (err error) {
+		defer mon.Task()(&ctx)(&err)
+		return nil
+	}
+	parent := func(ctx context.Context) (err error) {
+		defer mon.Task()(&ctx)(&err)
+		return child(ctx)
+	}
+
+	require.NoError(t, parent(context.Background()))
+	require.NoError(t, tp.ForceFlush(context.Background()))
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 2)
+
+	var parentSpan, childSpan tracetest.SpanStub
+	for _, span := range spans {
+		if span.Parent.SpanID().IsValid() {
+			childSpan = span
+		} else {
+			parentSpan = span
+		}
+	}
+
+	require.True(t, parentSpan.SpanContext.IsValid())
+	require.True(t, childSpan.SpanContext.IsValid())
+	require.Equal(t, parentSpan.SpanContext.TraceID(), childSpan.SpanContext.TraceID())
+	require.Equal(t, parentSpan.SpanContext.SpanID(), childSpan.Parent.SpanID())
+}
@@ -0,0 +1,351 @@
+// Copyright (C) 2024 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package version
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"flag"
+	"io"
+	"math/rand"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/zeebo/errs"
+	"go.uber.org/zap"
+
+	"storj.io/common/storj"
+)
+
+// MinCheckInterval is the smallest interval Updater will poll a versioncontrol server at,
+// regardless of what Config.CheckInterval is set to.
+const MinCheckInterval = 15 * time.Minute
+
+// ServiceManager restarts the host process's service after a binary swap, e.g. via systemd
+// or the Windows service manager. Implementations are platform specific and live outside this
+// package; Updater only calls Restart once the new binary is in place.
+type ServiceManager interface {
+	// Restart asks the service manager to restart the current service.
+	Restart() error
+}
+
+// Downloader fetches the bytes served at url, whether that's an AllowedVersions document or
+// a release binary.
+type Downloader interface {
+	Download(ctx context.Context, url string) ([]byte, error)
+}
+
+// HTTPDownloader is the default Downloader, backed by an *http.Client.
+type HTTPDownloader struct {
+	Client *http.Client
+}
+
+// Download implements Downloader.
+func (downloader HTTPDownloader) Download(ctx context.Context, url string) ([]byte, error) {
+	client := downloader.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, VerError.Wrap(err)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, VerError.Wrap(err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, VerError.New("unexpected status %d fetching %s", resp.StatusCode, url)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	return data, VerError.Wrap(err)
+}
+
+// Config configures an Updater.
+type Config struct {
+	// ServerAddress is the versioncontrol endpoint serving the AllowedVersions document.
+	ServerAddress string
+	// Process selects the Process entry of AllowedVersions.Processes that applies to this
+	// binary, e.g. "satellite", "storagenode", "uplink", "gateway", "identity".
+	Process string
+	// CheckInterval is how often to poll ServerAddress. Values below MinCheckInterval are
+	// raised to it.
+	CheckInterval time.Duration
+	// TrustedKeys, if non-empty, requires the AllowedVersions document fetched from
+	// ServerAddress to carry a detached signature - fetched from ServerAddress+".sig" - from
+	// one of these keys. See ParseTrustedKeys for turning a --version.trusted-keys flag into
+	// this field.
+	TrustedKeys []ed25519.PublicKey
+}
+
+// trustedKeys backs the --version.trusted-keys flag; NewUpdater falls back to it when
+// Config.TrustedKeys isn't set explicitly, so operators can rotate keys without a code change.
+var trustedKeys = flag.String("version.trusted-keys", "", "comma separated base64 ed25519 public keys trusted to sign AllowedVersions manifests")
+
+// ParseTrustedKeys parses a comma separated list of base64 standard encoded ed25519 public
+// keys, as accepted by a binary's --version.trusted-keys flag, into Config.TrustedKeys.
+func ParseTrustedKeys(flagValue string) ([]ed25519.PublicKey, error) {
+	if flagValue == "" {
+		return nil, nil
+	}
+
+	var keys []ed25519.PublicKey
+	for _, encoded := range strings.Split(flagValue, ",") {
+		raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+		if err != nil {
+			return nil, VerError.Wrap(err)
+		}
+		if len(raw) != ed25519.PublicKeySize {
+			return nil, VerError.New("invalid ed25519 public key length %d", len(raw))
+		}
+		keys = append(keys, ed25519.PublicKey(raw))
+	}
+	return keys, nil
+}
+
+// Updater periodically polls a versioncontrol server and, following the rollout embedded in
+// the returned AllowedVersions document, decides whether the running binary should be replaced.
+// It replaces the ad-hoc updater loops that used to live in each Storj binary.
+type Updater struct {
+	Log      *zap.Logger
+	Config   Config
+	Download Downloader
+	Service  ServiceManager
+
+	// Verify, if set, is run against a downloaded release binary before it replaces the
+	// currently running executable. It should return an error if the binary shouldn't be
+	// trusted.
+	Verify func(data []byte) error
+
+	// HealthCheck, if set, is run after Service.Restart to confirm the new binary actually
+	// came up healthy. An error here triggers the same rollback as a Restart failure; a nil
+	// Service.Restart doesn't by itself mean the new binary is working.
+	HealthCheck func() error
+
+	// NodeID identifies this node for rollout bucketing, see Rollout.Contains.
+	NodeID storj.NodeID
+	// CurrentVersion is the SemVer of the binary currently running.
+	CurrentVersion SemVer
+
+	// OnUpToDate, OnBelowMinimum and OnRolloutCandidate report the outcome of each check.
+	// Any of them may be left nil.
+	OnUpToDate         func(reason string)
+	OnBelowMinimum     func(target Version, reason string)
+	OnRolloutCandidate func(target Version, reason string)
+}
+
+// NewUpdater creates an Updater, clamping Config.CheckInterval to MinCheckInterval. If
+// config.TrustedKeys is empty, it falls back to whatever --version.trusted-keys was given on
+// the command line.
+func NewUpdater(log *zap.Logger, config Config) *Updater {
+	if config.CheckInterval < MinCheckInterval {
+		config.CheckInterval = MinCheckInterval
+	}
+
+	if len(config.TrustedKeys) == 0 {
+		keys, err := ParseTrustedKeys(*trustedKeys)
+		if err != nil {
+			log.Error("invalid --version.trusted-keys", zap.Error(err))
+		}
+		config.TrustedKeys = keys
+	}
+
+	return &Updater{
+		Log:      log,
+		Config:   config,
+		Download: HTTPDownloader{},
+	}
+}
+
+// Run polls Config.ServerAddress at Config.CheckInterval, plus jitter, until ctx is cancelled.
+// It does not apply updates itself; callers trigger Apply from the matching outcome callback.
+func (updater *Updater) Run(ctx context.Context) error {
+	interval := updater.Config.CheckInterval
+	if interval < MinCheckInterval {
+		interval = MinCheckInterval
+	}
+
+	for {
+		if err := updater.CheckOnce(ctx); err != nil {
+			updater.Log.Error("version check failed", zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(jitter(interval)):
+		}
+	}
+}
+
+// jitter returns d plus up to 10% random variation, so nodes polling the same versioncontrol
+// server don't all do so in lockstep.
+func jitter(d time.Duration) time.Duration {
+	return d + time.Duration(rand.Int63n(int64(d)/10+1))
+}
+
+// CheckOnce fetches the AllowedVersions document once, evaluates it via ShouldUpdateVersion
+// against updater.CurrentVersion and updater.NodeID, and invokes the matching outcome callback.
+func (updater *Updater) CheckOnce(ctx context.Context) error {
+	data, err := updater.Download.Download(ctx, updater.Config.ServerAddress)
+	if err != nil {
+		return VerError.Wrap(err)
+	}
+
+	allowed, err := updater.parseAllowedVersions(ctx, data)
+	if err != nil {
+		return err
+	}
+
+	process, err := allowed.Processes.Get(updater.Config.Process)
+	if err != nil {
+		return err
+	}
+
+	target, reason, err := ShouldUpdateVersion(updater.CurrentVersion, updater.NodeID, process)
+	if err != nil {
+		return err
+	}
+
+	switch reason {
+	case ReasonUpToDate:
+		if updater.OnUpToDate != nil {
+			updater.OnUpToDate(reason)
+		}
+	case ReasonBelowMinimum:
+		if updater.OnBelowMinimum != nil {
+			updater.OnBelowMinimum(target, reason)
+		}
+	case ReasonRolloutCandidate:
+		if updater.OnRolloutCandidate != nil {
+			updater.OnRolloutCandidate(target, reason)
+		}
+	}
+
+	return nil
+}
+
+// parseAllowedVersions parses data, requiring and checking a detached signature against
+// Config.TrustedKeys when any are configured.
+func (updater *Updater) parseAllowedVersions(ctx context.Context, data []byte) (AllowedVersions, error) {
+	if len(updater.Config.TrustedKeys) == 0 {
+		return NewAllowedVersions(data)
+	}
+
+	sig, err := updater.Download.Download(ctx, updater.Config.ServerAddress+".sig")
+	if err != nil {
+		return AllowedVersions{}, VerError.Wrap(err)
+	}
+
+	var lastErr error
+	for _, key := range updater.Config.TrustedKeys {
+		allowed, err := NewSigned(data, sig, key)
+		if err == nil {
+			return allowed, nil
+		}
+		lastErr = err
+	}
+	return AllowedVersions{}, VerError.Wrap(lastErr)
+}
+
+// Apply downloads target's binary, verifies it with updater.Verify if set, and atomically
+// replaces the currently running executable: the downloaded bytes are written to a temp file
+// next to exe, then swapped in with a single os.Rename(tmp, exe), so exe always resolves to a
+// valid binary, even across a crash. backup is a hard link to the pre-swap file, made before
+// the rename so exe is never unlinked (and therefore never briefly missing) to create it. If
+// restartAndVerify then fails - whether Service.Restart itself failed or HealthCheck rejected
+// the new binary - rollback restores backup over exe and restarts the service again, so Apply
+// never reports success while the running process is still on the binary that failed.
+func (updater *Updater) Apply(ctx context.Context, target Version) (err error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return VerError.Wrap(err)
+	}
+
+	return updater.applyTo(ctx, exe, target)
+}
+
+// applyTo is Apply with exe taken as a parameter instead of read from os.Executable, so tests
+// can exercise the rename/backup/rollback logic against a temp file.
+func (updater *Updater) applyTo(ctx context.Context, exe string, target Version) (err error) {
+	data, err := updater.Download.Download(ctx, target.URL)
+	if err != nil {
+		return VerError.Wrap(err)
+	}
+
+	if updater.Verify != nil {
+		if err := updater.Verify(data); err != nil {
+			return VerError.Wrap(err)
+		}
+	}
+
+	info, err := os.Stat(exe)
+	if err != nil {
+		return VerError.Wrap(err)
+	}
+
+	tmp := exe + ".new"
+	if err := os.WriteFile(tmp, data, info.Mode()); err != nil {
+		return VerError.Wrap(errs.Combine(err, os.Remove(tmp)))
+	}
+
+	backup := exe + ".bak"
+	_ = os.Remove(backup) // drop any stale backup left behind by a previous failed Apply
+	if err := os.Link(exe, backup); err != nil {
+		return VerError.Wrap(errs.Combine(err, os.Remove(tmp)))
+	}
+
+	if err := os.Rename(tmp, exe); err != nil {
+		return VerError.Wrap(errs.Combine(err, os.Remove(backup)))
+	}
+
+	if err := updater.restartAndVerify(); err != nil {
+		return updater.rollback(backup, exe, err)
+	}
+
+	return VerError.Wrap(os.Remove(backup))
+}
+
+// restartAndVerify restarts the service, if a ServiceManager is configured, and then runs
+// HealthCheck, if set, to confirm the new binary actually came up: a nil Restart error alone
+// doesn't mean the new binary is working. HealthCheck only says something about the new binary
+// once Service has actually restarted the process onto it, so it's skipped when Service is nil
+// - otherwise it would just be probing the still-running old process.
+func (updater *Updater) restartAndVerify() error {
+	if updater.Service == nil {
+		return nil
+	}
+	if err := updater.Service.Restart(); err != nil {
+		return err
+	}
+	if updater.HealthCheck != nil {
+		return updater.HealthCheck()
+	}
+	return nil
+}
+
+// rollback restores backup over exe and, if a ServiceManager is configured, restarts the
+// service again so the running process actually moves back onto the old binary, not just the
+// on-disk file. applyErr is the failure that triggered the rollback; it's always part of the
+// returned error, with any rollback failure combined in since that leaves the host in a worse
+// state than a clean rollback and the caller needs to know both.
+func (updater *Updater) rollback(backup, exe string, applyErr error) error {
+	if err := os.Rename(backup, exe); err != nil {
+		return VerError.Wrap(errs.Combine(applyErr, err))
+	}
+	if updater.Service != nil {
+		if err := updater.Service.Restart(); err != nil {
+			return VerError.Wrap(errs.Combine(applyErr, err))
+		}
+	}
+	return VerError.Wrap(applyErr)
+}
@@ -0,0 +1,126 @@
+// Copyright (C) 2024 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package version
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+// stubDownloader returns data for every Download call, regardless of url.
+type stubDownloader struct {
+	data []byte
+}
+
+func (s stubDownloader) Download(ctx context.Context, url string) ([]byte, error) {
+	return s.data, nil
+}
+
+// fakeService records every Restart call and fails it when shouldFail is set.
+type fakeService struct {
+	restarts   int
+	shouldFail bool
+}
+
+func (s *fakeService) Restart() error {
+	s.restarts++
+	if s.shouldFail {
+		return VerError.New("restart failed")
+	}
+	return nil
+}
+
+func TestApplyRollsBackOnFailedHealthCheck(t *testing.T) {
+	dir := t.TempDir()
+	exe := filepath.Join(dir, "binary")
+
+	oldData := []byte("old binary bytes")
+	newData := []byte("new binary bytes")
+	require.NoError(t, os.WriteFile(exe, oldData, 0755))
+
+	service := &fakeService{}
+	updater := &Updater{
+		Log:      zaptest.NewLogger(t),
+		Download: stubDownloader{data: newData},
+		Service:  service,
+		HealthCheck: func() error {
+			return VerError.New("new binary failed its health check")
+		},
+	}
+
+	err := updater.applyTo(context.Background(), exe, Version{URL: "http://example.test/binary"})
+	require.Error(t, err)
+
+	gotData, readErr := os.ReadFile(exe)
+	require.NoError(t, readErr)
+	require.Equal(t, oldData, gotData, "exe should be restored to the old binary after a failed health check")
+
+	require.Equal(t, 2, service.restarts, "service should be restarted once for the new binary and once more to roll back")
+
+	_, err = os.Stat(exe + ".bak")
+	require.True(t, os.IsNotExist(err), "backup file should be cleaned up")
+	_, err = os.Stat(exe + ".new")
+	require.True(t, os.IsNotExist(err), "temp file should be cleaned up")
+}
+
+func TestApplyRollsBackOnFailedRestart(t *testing.T) {
+	dir := t.TempDir()
+	exe := filepath.Join(dir, "binary")
+
+	oldData := []byte("old binary bytes")
+	newData := []byte("new binary bytes")
+	require.NoError(t, os.WriteFile(exe, oldData, 0755))
+
+	service := &fakeService{shouldFail: true}
+	updater := &Updater{
+		Log:      zaptest.NewLogger(t),
+		Download: stubDownloader{data: newData},
+		Service:  service,
+	}
+
+	err := updater.applyTo(context.Background(), exe, Version{URL: "http://example.test/binary"})
+	require.Error(t, err)
+
+	gotData, readErr := os.ReadFile(exe)
+	require.NoError(t, readErr)
+	require.Equal(t, oldData, gotData, "exe should be restored to the old binary when Restart itself fails")
+
+	require.Equal(t, 2, service.restarts, "rollback should retry the restart against the restored binary")
+}
+
+func TestApplySwapsInNewBinaryOnSuccess(t *testing.T) {
+	dir := t.TempDir()
+	exe := filepath.Join(dir, "binary")
+
+	oldData := []byte("old binary bytes")
+	newData := []byte("new binary bytes")
+	require.NoError(t, os.WriteFile(exe, oldData, 0755))
+
+	service := &fakeService{}
+	updater := &Updater{
+		Log:      zaptest.NewLogger(t),
+		Download: stubDownloader{data: newData},
+		Service:  service,
+		HealthCheck: func() error {
+			return nil
+		},
+	}
+
+	err := updater.applyTo(context.Background(), exe, Version{URL: "http://example.test/binary"})
+	require.NoError(t, err)
+
+	gotData, readErr := os.ReadFile(exe)
+	require.NoError(t, readErr)
+	require.Equal(t, newData, gotData)
+
+	require.Equal(t, 1, service.restarts)
+
+	_, err = os.Stat(exe + ".bak")
+	require.True(t, os.IsNotExist(err), "backup file should be cleaned up after a successful apply")
+}
@@ -5,7 +5,9 @@ package version
 
 import (
 	"bytes"
+	"crypto/ed25519"
 	"crypto/hmac"
+	"crypto/rand"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
@@ -98,6 +100,28 @@ type Process struct {
 	Rollout   Rollout `json:"rollout"`
 }
 
+// Get returns the Process entry matching a process name, as used by ShouldUpdateVersion
+// and the versioncontrol server, e.g. "satellite", "storagenode", "uplink", "gateway",
+// "identity".
+func (p Processes) Get(name string) (Process, error) {
+	switch name {
+	case "satellite":
+		return p.Satellite, nil
+	case "storagenode":
+		return p.Storagenode, nil
+	case "storagenode-updater":
+		return p.StoragenodeUpdater, nil
+	case "uplink":
+		return p.Uplink, nil
+	case "gateway":
+		return p.Gateway, nil
+	case "identity":
+		return p.Identity, nil
+	default:
+		return Process{}, VerError.New("unknown process %q", name)
+	}
+}
+
 // Version represents version and download URL for binary.
 type Version struct {
 	Version string `json:"version"`
@@ -110,6 +134,27 @@ type Rollout struct {
 	Cursor RolloutBytes `json:"cursor"`
 }
 
+// Contains returns whether nodeID falls within this rollout, i.e. whether a node with this ID
+// should receive the update the rollout describes.
+func (rollout Rollout) Contains(nodeID storj.NodeID) bool {
+	return isRolloutCandidate(nodeID, rollout)
+}
+
+// WithPercentage returns a new Rollout targeting pct percent of nodes: a freshly generated
+// random seed and the cursor recomputed to match, so rolling out a version can grow or shrink
+// a rollout without hand-rolling the big.Int math itself.
+func (rollout Rollout) WithPercentage(pct float64) Rollout {
+	var seed RolloutBytes
+	if _, err := rand.Read(seed[:]); err != nil {
+		panic(err)
+	}
+
+	return Rollout{
+		Seed:   seed,
+		Cursor: PercentageToCursorF(pct),
+	}
+}
+
 // RolloutBytes implements json un/marshalling using hex de/encoding.
 type RolloutBytes [32]byte
 
@@ -206,6 +251,37 @@ func New(data []byte) (v Info, err error) {
 	return v, VerError.Wrap(err)
 }
 
+// NewAllowedVersions parses an AllowedVersions document, as served by a versioncontrol
+// server's endpoint. It performs no authenticity check; use NewSigned where the document's
+// source isn't otherwise trusted.
+func NewAllowedVersions(data []byte) (allowed AllowedVersions, err error) {
+	err = json.Unmarshal(data, &allowed)
+	return allowed, VerError.Wrap(err)
+}
+
+// NewSigned parses an AllowedVersions document like NewAllowedVersions, but first verifies
+// the detached signature sig against pub, rejecting any manifest that wasn't signed by a
+// trusted key. This closes the trust gap that otherwise lets whoever controls the version URL
+// push arbitrary binaries at nodes that act on ShouldUpdateVersion.
+func NewSigned(data, sig []byte, pub ed25519.PublicKey) (AllowedVersions, error) {
+	if !ed25519.Verify(pub, data, sig) {
+		return AllowedVersions{}, VerError.New("AllowedVersions signature verification failed")
+	}
+	return NewAllowedVersions(data)
+}
+
+// Signer produces detached ed25519 signatures for AllowedVersions documents, for use by a
+// versioncontrol server. The matching public key is distributed to binaries so NewSigned can
+// verify what it fetches.
+type Signer struct {
+	PrivateKey ed25519.PrivateKey
+}
+
+// Sign returns data's detached signature.
+func (signer Signer) Sign(data []byte) []byte {
+	return ed25519.Sign(signer.PrivateKey, data)
+}
+
 // IsZero checks if the version struct is its zero value.
 func (info Info) IsZero() bool {
 	return reflect.ValueOf(info).IsZero()
@@ -281,6 +357,32 @@ func PercentageToCursorF(pct float64) RolloutBytes {
 	return cursor
 }
 
+// CursorToPercentageF calculates the floating point percentage of nodes which should update
+// for the given cursor value, rounded to four decimal places. It is the inverse of
+// PercentageToCursorF for percentages of at least 0.3907; below that, PercentageToCursorF's
+// cursor value is shorter than 32 bytes and is stored at the front of RolloutBytes, so the
+// percentage computed here is not the one it was given.
+func CursorToPercentageF(cursor RolloutBytes) float64 {
+	var maxInt, cursorInt big.Int
+	var maxBytes RolloutBytes
+	for i := 0; i < len(maxBytes); i++ {
+		maxBytes[i] = 255
+	}
+	maxInt.SetBytes(maxBytes[:])
+	cursorInt.SetBytes(cursor[:])
+
+	// scale up before dividing so the fractional part of the percentage survives the
+	// integer division, and round to nearest so a cursor from PercentageToCursorF, which is
+	// slightly below the exact fraction, maps back to its percentage.
+	var half big.Int
+	half.Rsh(&maxInt, 1)
+	cursorInt.Mul(&cursorInt, big.NewInt(100*10000))
+	cursorInt.Add(&cursorInt, &half)
+	cursorInt.Div(&cursorInt, &maxInt)
+
+	return float64(cursorInt.Int64()) / 10000
+}
+
 // PercentageToCursor calculates the cursor value for the given percentage of nodes which should update.
 // Deprecated: use PercentageToCursorF which is more precise.
 func PercentageToCursor(pct int) RolloutBytes {
@@ -314,6 +416,15 @@ func isRolloutCandidate(nodeID storj.NodeID, rollout Rollout) bool {
 	return bytes.Compare(hash.Sum(nil), rollout.Cursor[:]) <= 0
 }
 
+// Reasons returned alongside the outcome of ShouldUpdateVersion. They are exported so callers,
+// e.g. version.Updater, can switch on them without relying on the literal message text.
+const (
+	ReasonUpToDate         = "Version is up to date"
+	ReasonBelowMinimum     = "Version is below minimum allowed"
+	ReasonRolloutCandidate = "New version is being rolled out and this node is a candidate"
+	ReasonRolloutPending   = "New version is being rolled out but hasn't made it to this node yet"
+)
+
 // ShouldUpdateVersion determines if, given a current version and data from the version server, if
 // the current version should be updated. It returns the Version to update to or an empty Version.
 func ShouldUpdateVersion(currentVersion SemVer, nodeID storj.NodeID, requested Process) (updateVersion Version, reason string, err error) {
@@ -323,7 +434,7 @@ func ShouldUpdateVersion(currentVersion SemVer, nodeID storj.NodeID, requested P
 		return Version{}, "", err
 	}
 	if currentVersion.Compare(suggestedVersion) >= 0 {
-		return Version{}, "Version is up to date", nil
+		return Version{}, ReasonUpToDate, nil
 	}
 
 	// next, make sure we're at least running the minimum version. See
@@ -334,16 +445,16 @@ func ShouldUpdateVersion(currentVersion SemVer, nodeID storj.NodeID, requested P
 		return Version{}, "", err
 	}
 	if currentVersion.Compare(minimumVersion) < 0 {
-		return requested.Minimum, "Version is below minimum allowed", nil
+		return requested.Minimum, ReasonBelowMinimum, nil
 	}
 
 	// Okay, now consider the rollout
 	rollout := isRolloutCandidate(nodeID, requested.Rollout)
 	if rollout {
-		return requested.Suggested, "New version is being rolled out and this node is a candidate", nil
+		return requested.Suggested, ReasonRolloutCandidate, nil
 	}
 
-	return Version{}, "New version is being rolled out but hasn't made it to this node yet", nil
+	return Version{}, ReasonRolloutPending, nil
 }
 
 func getInfoFromBuildTags() Info {